@@ -4,21 +4,48 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io/fs"
+	"io"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/containers/podman/v2/pkg/ctime"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/contentstore"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/imageage"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/policy"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/scancache"
 	"github.com/eraser-dev/eraser/api/unversioned"
 	"github.com/eraser-dev/eraser/pkg/logger"
 	template "github.com/eraser-dev/eraser/pkg/scanners/template"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// storeBlobReader adapts a contentstore.ContentStore to imageage.BlobReader.
+type storeBlobReader struct {
+	store contentstore.ContentStore
+}
+
+func (r storeBlobReader) ReadBlob(digest string) ([]byte, error) {
+	rc, err := r.store.ReadBlob(digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
 const (
-	containerdDataDir = "/var/lib/containerd/io.containerd.content.v1.content"
+	// scannerVersion is recorded alongside cached scan results; bumping it
+	// invalidates the cache on upgrade, same as a policy change would.
+	scannerVersion = "0.1.0"
+
+	defaultCachePath = "/var/lib/eraser-scanner/cache.json"
+	defaultCacheTTL  = 10 * time.Minute
 )
 
 var (
@@ -32,8 +59,63 @@ var (
 )
 
 type Config struct {
-	// MaxAge is the oldest an image may be without being removed
+	// MaxAge is the oldest an image may be without being removed. Retained
+	// as a default policy when Policies is empty.
 	MaxAge string `json:"maxAge" yaml:"maxAge"`
+
+	// Policies are evaluated in order against each image's labels,
+	// annotations, tags, and age; the first match decides the image's
+	// fate. When empty, a single policy built from MaxAge is used.
+	Policies []policy.Policy `json:"policies,omitempty" yaml:"policies,omitempty"`
+
+	// Concurrency bounds how many images are scanned in parallel. Defaults
+	// to runtime.NumCPU() when unset or non-positive.
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+
+	// CachePath is where the scan-result cache is persisted between runs.
+	// Defaults to /var/lib/eraser-scanner/cache.json.
+	CachePath string `json:"cachePath,omitempty" yaml:"cachePath,omitempty"`
+
+	// CacheTTL is how long a cached scan result remains valid. Defaults to
+	// 10m.
+	CacheTTL string `json:"cacheTTL,omitempty" yaml:"cacheTTL,omitempty"`
+
+	// ContentStore selects which runtime's local image cache to scan.
+	// Defaults to the containerd content store for backward compatibility.
+	contentstore.Config
+}
+
+// policyCounters tallies, per policy name, how many images it decided the
+// fate of across the scan. It's surfaced only as a "policyDecisions" field
+// on the summary log line at the end of scan() - it isn't wired into
+// template.WithMetrics(true), which covers the image provider's own
+// metrics, not per-policy counts.
+type policyCounters struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newPolicyCounters() *policyCounters {
+	return &policyCounters{counts: make(map[string]int)}
+}
+
+func (c *policyCounters) record(name string) {
+	if name == "" {
+		name = "<none>"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[name]++
+}
+
+func (c *policyCounters) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.counts))
+	for k, v := range c.counts {
+		out[k] = v
+	}
+	return out
 }
 
 func main() {
@@ -61,14 +143,67 @@ func main() {
 		}
 	}
 
+	policies := c.Policies
+	if len(policies) == 0 {
+		policies = []policy.Policy{defaultMaxAgePolicy(maxAge)}
+	}
+	policyEngine, err := policy.NewEngine(policies)
+	if err != nil {
+		log.Error(err, "unable to compile retention policies")
+		os.Exit(1)
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	cachePath := c.CachePath
+	if cachePath == "" {
+		cachePath = defaultCachePath
+	}
+	cacheTTL := defaultCacheTTL
+	if c.CacheTTL != "" {
+		var err error
+		cacheTTL, err = time.ParseDuration(c.CacheTTL)
+		if err != nil {
+			log.Error(err, "unable to parse duration", "config.CacheTTL", c.CacheTTL)
+			os.Exit(1)
+		}
+	}
+
+	policyHash, err := policy.Hash(policies)
+	if err != nil {
+		log.Error(err, "unable to hash retention policies")
+		os.Exit(1)
+	}
+
+	cache, err := scancache.Load(cachePath)
+	if err != nil {
+		log.Error(err, "unable to load scan result cache", "path", cachePath)
+		os.Exit(1)
+	}
+
+	// ctx is canceled on SIGTERM/SIGINT; no timeout is applied to it. Once
+	// it is canceled, scan() starts no further per-image work, while work
+	// already in flight runs to completion.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
 	// create image provider with custom values
 	imageProvider := template.NewImageProvider(
-		template.WithContext(context.Background()),
+		template.WithContext(ctx),
 		template.WithMetrics(true),
 		template.WithDeleteScanFailedImages(true),
 		template.WithLogger(log),
 	)
 
+	store, err := contentstore.New(c.Config)
+	if err != nil {
+		log.Error(err, "unable to set up content store")
+		os.Exit(1)
+	}
+
 	// retrieve list of all non-running, non-excluded images from collector container
 	allImages, err := imageProvider.ReceiveImages()
 	if err != nil {
@@ -77,7 +212,11 @@ func main() {
 	}
 
 	// scan images with custom scanner
-	nonCompliant, failedImages := scan(allImages)
+	nonCompliant, failedImages := scan(ctx, allImages, store, policyEngine, concurrency, cache, policyHash, cacheTTL)
+
+	if err := cache.Save(); err != nil {
+		log.Error(err, "unable to persist scan result cache", "path", cachePath)
+	}
 
 	// send images to eraser container
 	if err := imageProvider.SendImages(nonCompliant, failedImages); err != nil {
@@ -92,12 +231,31 @@ func main() {
 	}
 }
 
+// defaultMaxAgePolicy builds the single-policy fallback used when no
+// policies are configured, preserving the scanner's original maxAge-only
+// behavior.
+func defaultMaxAgePolicy(maxAge time.Duration) policy.Policy {
+	return policy.Policy{
+		Name:           "default-max-age",
+		NonCompliantIf: fmt.Sprintf("age > duration(%q)", maxAge.String()),
+	}
+}
+
+// blobMatch pairs a matched digest from the content store walk with the
+// image it belongs to, so the expensive per-image work below can run
+// concurrently instead of inline in the (sequential) walk callback.
+type blobMatch struct {
+	digest string
+	mtime  time.Time
+	img    unversioned.Image
+}
+
 // TODO: implement customized scanner
-func scan(allImages []unversioned.Image) ([]unversioned.Image, []unversioned.Image) {
+func scan(ctx context.Context, allImages []unversioned.Image, store contentstore.ContentStore, policyEngine *policy.Engine, concurrency int, cache *scancache.Cache, policyHash string, cacheTTL time.Duration) ([]unversioned.Image, []unversioned.Image) {
 	// scan images and partition into non-compliant and failedImages
 	var nonCompliant, failedImages []unversioned.Image
 
-	// Create a set of the images, for use during the filesystem walk
+	// Create a set of the images, for use during the content store walk
 	digests := make(map[string]unversioned.Image, len(allImages))
 	for _, img := range allImages {
 		for _, dgst := range img.Digests {
@@ -106,44 +264,117 @@ func scan(allImages []unversioned.Image) ([]unversioned.Image, []unversioned.Ima
 		}
 	}
 
-	ctrFs := os.DirFS(containerdDataDir)
-	if err := fs.WalkDir(ctrFs, ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	// The walk itself is just directory listing and stat calls, so it stays
+	// sequential; only the manifest/config fetch and policy evaluation per
+	// match are dispatched concurrently below.
+	var matches []blobMatch
+	if err := store.WalkBlobs(func(digest string, size int64, mtime time.Time) error {
+		if img, shouldScan := digests[digest]; shouldScan {
+			matches = append(matches, blobMatch{digest: digest, mtime: mtime, img: img})
 		}
+		return nil
+	}); err != nil {
+		log.Error(err, "all images considered failed")
+		return []unversioned.Image{}, allImages
+	}
 
-		if d.IsDir() {
-			return nil
-		}
+	reader := storeBlobReader{store: store}
+	counters := newPolicyCounters()
 
-		img, shouldScan := digests[d.Name()]
-		if !shouldScan {
-			return nil
-		}
+	var mu sync.Mutex
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(concurrency)
 
-		info, err := d.Info()
-		if err != nil {
-			failedImages = append(failedImages, img)
-			return nil
-		}
+	for _, m := range matches {
+		m := m
+		eg.Go(func() error {
+			if err := egCtx.Err(); err != nil {
+				return err
+			}
 
-		created := ctime.Created(info)
-		log.Info("image scanned", "image", img, "created_at", created.String(), "image age", time.Since(created).String())
-		if time.Since(created) > maxAge {
-			nonCompliant = append(nonCompliant, img)
-		}
+			if cached, ok := cache.Lookup(m.digest, policyHash, scannerVersion, cacheTTL); ok {
+				mu.Lock()
+				defer mu.Unlock()
 
-		return nil
-	}); err != nil {
-		log.Error(err, "all images considered failed")
-		return []unversioned.Image{}, allImages
+				counters.record(cached.Policy)
+				log.Info("image scanned (cached)", "image", m.img, "created_at", cached.Created.String(),
+					"policy", cached.Policy, "decision", cached.Decision)
+				switch cached.Decision {
+				case scancache.DecisionNonCompliant:
+					nonCompliant = append(nonCompliant, m.img)
+				case scancache.DecisionFailed:
+					failedImages = append(failedImages, m.img)
+				}
+				return nil
+			}
+
+			info, err := imageage.Resolve(reader, m.digest)
+			if err != nil {
+				log.Info("falling back to blob ctime", "image", m.img, "reason", err.Error())
+				info = imageage.Info{Created: m.mtime}
+			}
+
+			age := time.Since(info.Created)
+			decision, err := policyEngine.Evaluate(policy.Image{
+				Labels:      info.Labels,
+				Annotations: info.Annotations,
+				Tags:        imageTags(m.img),
+				Age:         age,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				log.Error(err, "unable to evaluate retention policies", "image", m.img)
+				failedImages = append(failedImages, m.img)
+				cache.Store(m.digest, scancache.Entry{
+					Decision:       scancache.DecisionFailed,
+					Created:        info.Created,
+					PolicyHash:     policyHash,
+					ScannerVersion: scannerVersion,
+					CachedAt:       time.Now(),
+				})
+				return nil
+			}
+
+			counters.record(decision.Policy)
+			log.Info("image scanned", "image", m.img, "created_at", info.Created.String(), "image age", age.String(),
+				"policy", decision.Policy, "compliant", decision.Compliant)
+
+			cacheDecision := scancache.DecisionCompliant
+			if !decision.Compliant {
+				cacheDecision = scancache.DecisionNonCompliant
+				nonCompliant = append(nonCompliant, m.img)
+			}
+			cache.Store(m.digest, scancache.Entry{
+				Decision:       cacheDecision,
+				Created:        info.Created,
+				Policy:         decision.Policy,
+				PolicyHash:     policyHash,
+				ScannerVersion: scannerVersion,
+				CachedAt:       time.Now(),
+			})
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		log.Info("scan canceled before all images were processed", "reason", err.Error())
 	}
 
-	log.Info("images", "nonCompliant", nonCompliant, "failed", failedImages)
+	log.Info("images", "nonCompliant", nonCompliant, "failed", failedImages, "policyDecisions", counters.snapshot())
 
 	return nonCompliant, failedImages
 }
 
+// imageTags returns the repo tag(s) associated with img, for use in policy
+// expressions like `tags.exists(t, t.matches("^v[0-9]+"))`.
+func imageTags(img unversioned.Image) []string {
+	return img.Names
+}
+
 func loadConfig(filename string) (Config, error) {
 	cfg := Config{MaxAge: "168h"}
 
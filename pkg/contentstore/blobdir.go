@@ -0,0 +1,46 @@
+package contentstore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containers/podman/v2/pkg/ctime"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/imageage"
+)
+
+// flatDigestDir implements the walk/read behavior shared by the containerd,
+// Docker, and OCI-layout backends: a single directory whose entries are
+// files named by bare hex digest. containerd, Docker's imagedb, and an OCI
+// layout's blobs/sha256 all take this shape; only the path to that directory
+// differs per backend. The CRI-O backend uses its own per-image layout.
+type flatDigestDir struct {
+	dir string
+}
+
+func (f flatDigestDir) walk(fn func(digest string, size int64, mtime time.Time) error) error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", f.dir, err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		if err := fn(e.Name(), info.Size(), ctime.Created(info)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f flatDigestDir) read(digest string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(f.dir, imageage.TrimDigest(digest)))
+}
@@ -0,0 +1,32 @@
+package contentstore
+
+import (
+	"io"
+	"path/filepath"
+	"time"
+)
+
+const defaultContainerdDataDir = "/var/lib/containerd/io.containerd.content.v1.content"
+
+// containerdStore reads containerd's local content store, where blobs live
+// at <dataDir>/blobs/sha256/<digest>.
+type containerdStore struct {
+	blobs flatDigestDir
+}
+
+func newContainerdStore(dataDir string) *containerdStore {
+	if dataDir == "" {
+		dataDir = defaultContainerdDataDir
+	}
+	return &containerdStore{blobs: flatDigestDir{dir: filepath.Join(dataDir, "blobs", "sha256")}}
+}
+
+func (s *containerdStore) Kind() string { return "containerd" }
+
+func (s *containerdStore) WalkBlobs(fn func(digest string, size int64, mtime time.Time) error) error {
+	return s.blobs.walk(fn)
+}
+
+func (s *containerdStore) ReadBlob(digest string) (io.ReadCloser, error) {
+	return s.blobs.read(digest)
+}
@@ -0,0 +1,60 @@
+// Package contentstore abstracts over the on-disk layouts different
+// container runtimes use to keep image content, so the scanner can walk and
+// read manifest/config blobs without hardcoding containerd's content store.
+package contentstore
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ContentStore walks and reads the sha256-addressed blobs backing a
+// runtime's local image cache.
+type ContentStore interface {
+	// WalkBlobs invokes fn once per blob found in the store, with the bare
+	// hex digest (no "sha256:" prefix), its size, and its on-disk ctime
+	// (as reported by ctime.Created). Walking stops at the first error fn
+	// returns.
+	WalkBlobs(fn func(digest string, size int64, mtime time.Time) error) error
+
+	// ReadBlob returns the contents of the blob with the given digest.
+	// digest may be given with or without the "sha256:" prefix. Callers
+	// must close the returned reader.
+	ReadBlob(digest string) (io.ReadCloser, error)
+
+	// Kind identifies which backend this store implements, e.g.
+	// "containerd", "crio", "docker", or "oci-layout".
+	Kind() string
+}
+
+// Config selects and configures a ContentStore backend. It is embedded in
+// the scanner's Config so it can be set from the scanner's YAML config
+// block.
+type Config struct {
+	// Runtime selects the backend: "containerd" (default), "crio",
+	// "docker", or "oci-layout".
+	Runtime string `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+
+	// DataDir overrides the backend's default data directory.
+	DataDir string `json:"dataDir,omitempty" yaml:"dataDir,omitempty"`
+}
+
+// New builds the ContentStore selected by cfg.
+func New(cfg Config) (ContentStore, error) {
+	switch cfg.Runtime {
+	case "", "containerd":
+		return newContainerdStore(cfg.DataDir), nil
+	case "crio":
+		return newCrioStore(cfg.DataDir), nil
+	case "docker":
+		return newDockerStore(cfg.DataDir), nil
+	case "oci-layout":
+		if cfg.DataDir == "" {
+			return nil, fmt.Errorf("contentstore: oci-layout runtime requires dataDir")
+		}
+		return newOCILayoutStore(cfg.DataDir), nil
+	default:
+		return nil, fmt.Errorf("contentstore: unsupported runtime %q", cfg.Runtime)
+	}
+}
@@ -0,0 +1,147 @@
+package contentstore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func readAll(t *testing.T, rc io.ReadCloser) string {
+	t.Helper()
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	return string(b)
+}
+
+func TestOCILayoutStoreWalkAndRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "blobs", "sha256", "abc123"), "manifest-bytes")
+
+	s := newOCILayoutStore(dir)
+
+	var digests []string
+	if err := s.WalkBlobs(func(digest string, size int64, mtime time.Time) error {
+		digests = append(digests, digest)
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkBlobs: %v", err)
+	}
+	if len(digests) != 1 || digests[0] != "abc123" {
+		t.Fatalf("WalkBlobs digests = %v, want [abc123]", digests)
+	}
+
+	rc, err := s.ReadBlob("sha256:abc123")
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if got := readAll(t, rc); got != "manifest-bytes" {
+		t.Errorf("ReadBlob contents = %q, want %q", got, "manifest-bytes")
+	}
+}
+
+func TestDockerStoreResolvesDriverDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "image", "overlay2", "imagedb", "content", "sha256", "abc123"), "config-bytes")
+
+	s := newDockerStore(dir)
+
+	rc, err := s.ReadBlob("abc123")
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if got := readAll(t, rc); got != "config-bytes" {
+		t.Errorf("ReadBlob contents = %q, want %q", got, "config-bytes")
+	}
+}
+
+func TestDockerStoreErrorsWithNoImagedb(t *testing.T) {
+	s := newDockerStore(t.TempDir())
+	if _, err := s.ReadBlob("abc123"); err == nil {
+		t.Fatal("ReadBlob did not error with no imagedb present")
+	}
+}
+
+func TestCrioStoreWalkSkipsMissingManifest(t *testing.T) {
+	dir := t.TempDir()
+	imagesDir := filepath.Join(dir, "overlay-images")
+	writeFile(t, filepath.Join(imagesDir, "img1", "manifest"), "manifest-1")
+	// img2 has no manifest file - e.g. a partially-pulled or non-image entry.
+	if err := os.MkdirAll(filepath.Join(imagesDir, "img2"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	s := newCrioStore(dir)
+
+	var digests []string
+	if err := s.WalkBlobs(func(digest string, size int64, mtime time.Time) error {
+		digests = append(digests, digest)
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkBlobs: %v", err)
+	}
+	if len(digests) != 1 || digests[0] != "img1" {
+		t.Fatalf("WalkBlobs digests = %v, want [img1]", digests)
+	}
+}
+
+func TestCrioStoreReadBlobDispatchesManifestVsConfig(t *testing.T) {
+	dir := t.TempDir()
+	imagesDir := filepath.Join(dir, "overlay-images")
+	writeFile(t, filepath.Join(imagesDir, "img1", "manifest"), "manifest-bytes")
+	writeFile(t, filepath.Join(imagesDir, "img1", "configdigest"), "config-bytes")
+
+	s := newCrioStore(dir)
+
+	rc, err := s.ReadBlob("img1")
+	if err != nil {
+		t.Fatalf("ReadBlob(manifest digest): %v", err)
+	}
+	if got := readAll(t, rc); got != "manifest-bytes" {
+		t.Errorf("ReadBlob(manifest digest) = %q, want %q", got, "manifest-bytes")
+	}
+
+	rc, err = s.ReadBlob("sha256:configdigest")
+	if err != nil {
+		t.Fatalf("ReadBlob(config digest): %v", err)
+	}
+	if got := readAll(t, rc); got != "config-bytes" {
+		t.Errorf("ReadBlob(config digest) = %q, want %q", got, "config-bytes")
+	}
+}
+
+func TestCrioStoreReadBlobNotFound(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "overlay-images", "img1", "manifest"), "manifest-bytes")
+
+	s := newCrioStore(dir)
+	if _, err := s.ReadBlob("sha256:missing"); err == nil {
+		t.Fatal("ReadBlob did not error for an unknown digest")
+	}
+}
+
+func TestNewUnsupportedRuntime(t *testing.T) {
+	if _, err := New(Config{Runtime: "bogus"}); err == nil {
+		t.Fatal("New did not error for an unsupported runtime")
+	}
+}
+
+func TestNewOCILayoutRequiresDataDir(t *testing.T) {
+	if _, err := New(Config{Runtime: "oci-layout"}); err == nil {
+		t.Fatal("New did not error for oci-layout without a dataDir")
+	}
+}
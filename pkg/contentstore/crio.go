@@ -0,0 +1,91 @@
+package contentstore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containers/podman/v2/pkg/ctime"
+	"github.com/eraser-dev/eraser-scanner-template/pkg/imageage"
+)
+
+const defaultCrioDataDir = "/var/lib/containers/storage"
+
+// crioStore reads the containers/storage layout CRI-O uses, where each
+// image's manifest lives at <dataDir>/overlay-images/<digest>/manifest.
+// Blobs a manifest references (the config, in particular) aren't kept in
+// their own top-level directory; containers/storage persists them as "big
+// data" items inside the owning image's directory, named by their own
+// digest.
+type crioStore struct {
+	imagesDir string
+}
+
+func newCrioStore(dataDir string) *crioStore {
+	if dataDir == "" {
+		dataDir = defaultCrioDataDir
+	}
+	return &crioStore{imagesDir: filepath.Join(dataDir, "overlay-images")}
+}
+
+func (s *crioStore) Kind() string { return "crio" }
+
+func (s *crioStore) manifestPath(digest string) string {
+	return filepath.Join(s.imagesDir, imageage.TrimDigest(digest), "manifest")
+}
+
+func (s *crioStore) WalkBlobs(fn func(digest string, size int64, mtime time.Time) error) error {
+	entries, err := os.ReadDir(s.imagesDir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", s.imagesDir, err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(s.imagesDir, e.Name(), "manifest"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := fn(e.Name(), info.Size(), ctime.Created(info)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBlob serves two distinct shapes of request: digest addressing an
+// image's manifest (the common case, keyed by the per-image directory
+// name), and digest addressing a blob a manifest references - notably the
+// config - which containers/storage stores as a same-digest-named file
+// inside whichever image directory owns it.
+func (s *crioStore) ReadBlob(digest string) (io.ReadCloser, error) {
+	if f, err := os.Open(s.manifestPath(digest)); err == nil {
+		return f, nil
+	}
+
+	entries, err := os.ReadDir(s.imagesDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.imagesDir, err)
+	}
+
+	d := imageage.TrimDigest(digest)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if f, err := os.Open(filepath.Join(s.imagesDir, e.Name(), d)); err == nil {
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("crio: blob %s not found under %s", digest, s.imagesDir)
+}
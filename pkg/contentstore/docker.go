@@ -0,0 +1,67 @@
+package contentstore
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+const defaultDockerDataDir = "/var/lib/docker"
+
+// dockerStore reads the Docker daemon's graph-driver image database, where
+// entries live at <dataDir>/image/<driver>/imagedb/content/sha256/<digest>.
+// The driver directory name (overlay2, aufs, ...) isn't fixed, so it's
+// resolved lazily the first time the store is used.
+//
+// Unlike the other backends, imagedb never holds a manifest envelope - each
+// entry is the image config document itself, keyed by the config's own
+// digest (the image ID). imageage.Resolve handles that shape directly, so
+// ReadBlob can serve it unchanged.
+type dockerStore struct {
+	dataDir string
+	blobs   *flatDigestDir
+}
+
+func newDockerStore(dataDir string) *dockerStore {
+	if dataDir == "" {
+		dataDir = defaultDockerDataDir
+	}
+	return &dockerStore{dataDir: dataDir}
+}
+
+func (s *dockerStore) Kind() string { return "docker" }
+
+func (s *dockerStore) resolve() (flatDigestDir, error) {
+	if s.blobs != nil {
+		return *s.blobs, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.dataDir, "image", "*", "imagedb", "content", "sha256"))
+	if err != nil {
+		return flatDigestDir{}, fmt.Errorf("globbing docker imagedb under %s: %w", s.dataDir, err)
+	}
+	if len(matches) == 0 {
+		return flatDigestDir{}, fmt.Errorf("no docker imagedb found under %s", s.dataDir)
+	}
+
+	blobs := flatDigestDir{dir: matches[0]}
+	s.blobs = &blobs
+	return blobs, nil
+}
+
+func (s *dockerStore) WalkBlobs(fn func(digest string, size int64, mtime time.Time) error) error {
+	blobs, err := s.resolve()
+	if err != nil {
+		return err
+	}
+	return blobs.walk(fn)
+}
+
+func (s *dockerStore) ReadBlob(digest string) (io.ReadCloser, error) {
+	blobs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return blobs.read(digest)
+}
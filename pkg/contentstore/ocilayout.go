@@ -0,0 +1,29 @@
+package contentstore
+
+import (
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// ociLayoutStore reads a generic OCI image-layout directory (an index.json
+// plus a blobs/sha256 directory), as produced by `skopeo copy` or `crane
+// pull` to a local path. Mainly useful for pointing the scanner at a fixture
+// directory in tests.
+type ociLayoutStore struct {
+	blobs flatDigestDir
+}
+
+func newOCILayoutStore(dataDir string) *ociLayoutStore {
+	return &ociLayoutStore{blobs: flatDigestDir{dir: filepath.Join(dataDir, "blobs", "sha256")}}
+}
+
+func (s *ociLayoutStore) Kind() string { return "oci-layout" }
+
+func (s *ociLayoutStore) WalkBlobs(fn func(digest string, size int64, mtime time.Time) error) error {
+	return s.blobs.walk(fn)
+}
+
+func (s *ociLayoutStore) ReadBlob(digest string) (io.ReadCloser, error) {
+	return s.blobs.read(digest)
+}
@@ -0,0 +1,168 @@
+// Package imageage derives an image's build-time metadata - its `created`
+// timestamp, OCI config labels, and manifest annotations - from its
+// OCI/Docker manifest and config, rather than from local blob filesystem
+// metadata. A containerd content-store blob's ctime reflects when that blob
+// was written to the local store, which is refreshed on every `pull` even
+// when the image itself is unchanged - leading to false positives when
+// comparing against a max-age retention policy. The config's `created`
+// field, on the other hand, is set once at build time and travels with the
+// image across pulls and hosts.
+package imageage
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIManifestList    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// descriptor mirrors the OCI content descriptor, trimmed to the fields we
+// actually read.
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *platform `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// manifest covers both a single-platform image manifest (Config is
+// populated) and a manifest list / image index (Manifests is populated).
+type manifest struct {
+	MediaType   string            `json:"mediaType"`
+	Config      descriptor        `json:"config"`
+	Manifests   []descriptor      `json:"manifests,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type imageConfig struct {
+	Created *time.Time   `json:"created"`
+	Config  configLabels `json:"config"`
+}
+
+type configLabels struct {
+	Labels map[string]string `json:"Labels"`
+}
+
+// BlobReader fetches the raw bytes of a blob by its "sha256:<hex>" or bare
+// hex digest. It is satisfied by contentstore.ContentStore.ReadBlob plus an
+// io.ReadAll, but is kept minimal here so this package doesn't need to
+// import contentstore.
+type BlobReader interface {
+	ReadBlob(digest string) ([]byte, error)
+}
+
+// Info is the subset of an image's manifest/config metadata the scanner's
+// retention policies evaluate.
+type Info struct {
+	Created     time.Time
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Resolve reads the manifest at digest and, descending through a manifest
+// list if necessary, the config blob it references, returning the image's
+// created timestamp, config labels, and manifest annotations.
+func Resolve(r BlobReader, digest string) (Info, error) {
+	b, err := r.ReadBlob(digest)
+	if err != nil {
+		return Info{}, fmt.Errorf("reading manifest blob %s: %w", digest, err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Info{}, fmt.Errorf("parsing manifest blob %s: %w", digest, err)
+	}
+
+	switch m.MediaType {
+	case mediaTypeOCIManifestList, mediaTypeDockerManifestList:
+		entry, err := selectPlatform(m.Manifests)
+		if err != nil {
+			return Info{}, fmt.Errorf("manifest list %s: %w", digest, err)
+		}
+		return Resolve(r, entry.Digest)
+	case mediaTypeOCIManifest, mediaTypeDockerManifest, "":
+		// Some registries omit mediaType on single-platform manifests; a
+		// populated Config descriptor is the tell.
+		if m.Config.Digest == "" {
+			// Some content stores (e.g. the Docker daemon's local imagedb)
+			// never keep the manifest envelope at all - only the config
+			// blob it would have referenced, addressed by the config's own
+			// digest. Accept that shape directly rather than erroring, so
+			// those stores still yield a real `created` timestamp instead
+			// of silently falling back to blob ctime.
+			if cfg, ok := asImageConfig(b); ok {
+				return Info{Created: *cfg.Created, Labels: cfg.Config.Labels}, nil
+			}
+			return Info{}, fmt.Errorf("manifest %s: not a recognized image manifest", digest)
+		}
+	default:
+		return Info{}, fmt.Errorf("manifest %s: unsupported mediaType %q", digest, m.MediaType)
+	}
+
+	cb, err := r.ReadBlob(m.Config.Digest)
+	if err != nil {
+		return Info{}, fmt.Errorf("reading config blob %s: %w", m.Config.Digest, err)
+	}
+
+	var cfg imageConfig
+	if err := json.Unmarshal(cb, &cfg); err != nil {
+		return Info{}, fmt.Errorf("parsing config blob %s: %w", m.Config.Digest, err)
+	}
+	if cfg.Created == nil {
+		return Info{}, fmt.Errorf("config blob %s: missing created field", m.Config.Digest)
+	}
+
+	return Info{
+		Created:     *cfg.Created,
+		Labels:      cfg.Config.Labels,
+		Annotations: m.Annotations,
+	}, nil
+}
+
+// asImageConfig reports whether b parses as a bare image config document -
+// the shape some content stores (e.g. Docker's local imagedb) persist
+// in place of a manifest envelope - with a populated created timestamp.
+func asImageConfig(b []byte) (imageConfig, bool) {
+	var cfg imageConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return imageConfig{}, false
+	}
+	if cfg.Created == nil {
+		return imageConfig{}, false
+	}
+	return cfg, true
+}
+
+func selectPlatform(entries []descriptor) (descriptor, error) {
+	for _, e := range entries {
+		if e.Platform == nil {
+			continue
+		}
+		if e.Platform.OS == runtime.GOOS && e.Platform.Architecture == runtime.GOARCH {
+			return e, nil
+		}
+	}
+	if len(entries) > 0 {
+		return entries[0], nil
+	}
+	return descriptor{}, fmt.Errorf("no entries for platform %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// TrimDigest strips the "sha256:" algorithm prefix some call sites carry,
+// matching the bare hex filenames used as blob keys on disk.
+func TrimDigest(digest string) string {
+	return strings.TrimPrefix(digest, "sha256:")
+}
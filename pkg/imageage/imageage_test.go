@@ -0,0 +1,122 @@
+package imageage
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type fakeBlobReader map[string][]byte
+
+func (f fakeBlobReader) ReadBlob(digest string) ([]byte, error) {
+	b, ok := f[digest]
+	if !ok {
+		return nil, fmt.Errorf("no blob for digest %s", digest)
+	}
+	return b, nil
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+func TestResolveOCIManifest(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := imageConfig{Created: &created, Config: configLabels{Labels: map[string]string{"team": "infra"}}}
+
+	r := fakeBlobReader{
+		"sha256:manifest": mustMarshal(t, manifest{
+			MediaType:   mediaTypeOCIManifest,
+			Config:      descriptor{Digest: "sha256:config"},
+			Annotations: map[string]string{"org.opencontainers.image.source": "example"},
+		}),
+		"sha256:config": mustMarshal(t, cfg),
+	}
+
+	info, err := Resolve(r, "sha256:manifest")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !info.Created.Equal(created) {
+		t.Errorf("Created = %v, want %v", info.Created, created)
+	}
+	if info.Labels["team"] != "infra" {
+		t.Errorf("Labels[team] = %q, want %q", info.Labels["team"], "infra")
+	}
+	if info.Annotations["org.opencontainers.image.source"] != "example" {
+		t.Errorf("missing expected annotation")
+	}
+}
+
+func TestResolveManifestListSelectsPlatform(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := imageConfig{Created: &created}
+
+	r := fakeBlobReader{
+		"sha256:list": mustMarshal(t, manifest{
+			MediaType: mediaTypeOCIManifestList,
+			Manifests: []descriptor{
+				{Digest: "sha256:other", Platform: &platform{OS: "windows", Architecture: "amd64"}},
+				{Digest: "sha256:match", Platform: &platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}},
+			},
+		}),
+		"sha256:match": mustMarshal(t, manifest{
+			MediaType: mediaTypeOCIManifest,
+			Config:    descriptor{Digest: "sha256:config"},
+		}),
+		"sha256:config": mustMarshal(t, cfg),
+	}
+
+	info, err := Resolve(r, "sha256:list")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !info.Created.Equal(created) {
+		t.Errorf("Created = %v, want %v", info.Created, created)
+	}
+}
+
+func TestResolveConfigOnlyBlobFallback(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := imageConfig{Created: &created, Config: configLabels{Labels: map[string]string{"team": "infra"}}}
+
+	r := fakeBlobReader{"sha256:config": mustMarshal(t, cfg)}
+
+	info, err := Resolve(r, "sha256:config")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !info.Created.Equal(created) {
+		t.Errorf("Created = %v, want %v", info.Created, created)
+	}
+	if info.Labels["team"] != "infra" {
+		t.Errorf("Labels[team] = %q, want %q", info.Labels["team"], "infra")
+	}
+}
+
+func TestResolveUnrecognizedBlobErrors(t *testing.T) {
+	r := fakeBlobReader{"sha256:junk": []byte(`{"foo":"bar"}`)}
+
+	if _, err := Resolve(r, "sha256:junk"); err == nil {
+		t.Fatal("Resolve did not error on an unrecognized blob")
+	}
+}
+
+func TestTrimDigest(t *testing.T) {
+	cases := map[string]string{
+		"sha256:abc123": "abc123",
+		"abc123":        "abc123",
+	}
+	for in, want := range cases {
+		if got := TrimDigest(in); got != want {
+			t.Errorf("TrimDigest(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package policy evaluates image retention decisions from CEL boolean
+// expressions over an image's config labels, manifest annotations, repo
+// tags, and age, replacing a single hardcoded max-age check with a
+// configurable, ordered set of policies.
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Policy is a named pair of CEL boolean expressions evaluated against an
+// image. KeepIf is checked first: a match always keeps the image, even if
+// NonCompliantIf would otherwise also match. Expressions see four
+// variables: labels (map[string]string), annotations (map[string]string),
+// tags ([]string of repo tags, checked with e.g.
+// `tags.exists(t, t.matches("^v[0-9]+"))`), and age (a duration, e.g. `age >
+// duration("168h")`).
+type Policy struct {
+	Name           string `json:"name" yaml:"name"`
+	KeepIf         string `json:"keepIf,omitempty" yaml:"keepIf,omitempty"`
+	NonCompliantIf string `json:"nonCompliantIf,omitempty" yaml:"nonCompliantIf,omitempty"`
+}
+
+// Hash returns a stable hex digest of policies, so callers (e.g. a scan
+// result cache) can detect when the policy configuration has changed and
+// invalidate anything evaluated under an older set.
+func Hash(policies []Policy) (string, error) {
+	b, err := json.Marshal(policies)
+	if err != nil {
+		return "", fmt.Errorf("policy: hashing policies: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Image is the subset of an image's metadata policies are evaluated
+// against.
+type Image struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Tags        []string
+	Age         time.Duration
+}
+
+// Decision records whether an image was kept or flagged non-compliant, and
+// which policy made that call.
+type Decision struct {
+	Compliant bool
+	Policy    string // name of the deciding policy, or "" if none matched
+}
+
+type compiledPolicy struct {
+	name           string
+	keepIf         cel.Program
+	nonCompliantIf cel.Program
+}
+
+// Engine evaluates an ordered set of compiled policies against images. The
+// first policy whose KeepIf or NonCompliantIf expression matches decides the
+// outcome.
+type Engine struct {
+	policies []compiledPolicy
+}
+
+// NewEngine compiles policies against a CEL environment exposing labels,
+// annotations, tags, and age. Policy order is preserved from the input
+// slice.
+func NewEngine(policies []Policy) (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("annotations", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("tags", cel.ListType(cel.StringType)),
+		cel.Variable("age", cel.DurationType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("policy: creating CEL environment: %w", err)
+	}
+
+	e := &Engine{policies: make([]compiledPolicy, 0, len(policies))}
+	for _, p := range policies {
+		c := compiledPolicy{name: p.Name}
+
+		if p.KeepIf != "" {
+			prg, err := compile(env, p.KeepIf)
+			if err != nil {
+				return nil, fmt.Errorf("policy %q: compiling keepIf: %w", p.Name, err)
+			}
+			c.keepIf = prg
+		}
+
+		if p.NonCompliantIf != "" {
+			prg, err := compile(env, p.NonCompliantIf)
+			if err != nil {
+				return nil, fmt.Errorf("policy %q: compiling nonCompliantIf: %w", p.Name, err)
+			}
+			c.nonCompliantIf = prg
+		}
+
+		e.policies = append(e.policies, c)
+	}
+
+	return e, nil
+}
+
+func compile(env *cel.Env, expr string) (cel.Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	return env.Program(ast)
+}
+
+// Evaluate runs each policy's expressions against img in order, returning
+// the decision from the first match. An image matching no policy is treated
+// as compliant.
+func (e *Engine) Evaluate(img Image) (Decision, error) {
+	vars := map[string]interface{}{
+		"labels":      stringMap(img.Labels),
+		"annotations": stringMap(img.Annotations),
+		"tags":        img.Tags,
+		"age":         img.Age,
+	}
+
+	for _, p := range e.policies {
+		if p.keepIf != nil {
+			matched, err := evalBool(p.keepIf, vars)
+			if err != nil {
+				return Decision{}, fmt.Errorf("policy %q: evaluating keepIf: %w", p.name, err)
+			}
+			if matched {
+				return Decision{Compliant: true, Policy: p.name}, nil
+			}
+		}
+
+		if p.nonCompliantIf != nil {
+			matched, err := evalBool(p.nonCompliantIf, vars)
+			if err != nil {
+				return Decision{}, fmt.Errorf("policy %q: evaluating nonCompliantIf: %w", p.name, err)
+			}
+			if matched {
+				return Decision{Compliant: false, Policy: p.name}, nil
+			}
+		}
+	}
+
+	return Decision{Compliant: true}, nil
+}
+
+// stringMap guards against nil maps, which CEL's map type doesn't accept.
+func stringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+func evalBool(prg cel.Program, vars map[string]interface{}) (bool, error) {
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("evaluating expression: %w", err)
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression did not evaluate to a bool (got %T)", out.Value())
+	}
+	return b, nil
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateKeepIfTakesPrecedenceOverNonCompliantIf(t *testing.T) {
+	e, err := NewEngine([]Policy{{
+		Name:           "keep-pinned",
+		KeepIf:         `"pin" in labels && labels["pin"] == "true"`,
+		NonCompliantIf: `age > duration("1h")`,
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	d, err := e.Evaluate(Image{
+		Labels: map[string]string{"pin": "true"},
+		Age:    48 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if !d.Compliant || d.Policy != "keep-pinned" {
+		t.Fatalf("Evaluate() = %+v, want a compliant decision from keep-pinned", d)
+	}
+}
+
+func TestEvaluateFallsThroughToNonCompliantIf(t *testing.T) {
+	e, err := NewEngine([]Policy{{
+		Name:           "max-age",
+		KeepIf:         `"pin" in labels && labels["pin"] == "true"`,
+		NonCompliantIf: `age > duration("1h")`,
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	d, err := e.Evaluate(Image{Age: 48 * time.Hour})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if d.Compliant || d.Policy != "max-age" {
+		t.Fatalf("Evaluate() = %+v, want a non-compliant decision from max-age", d)
+	}
+}
+
+func TestEvaluateNoPolicyMatchesIsCompliant(t *testing.T) {
+	e, err := NewEngine([]Policy{{
+		Name:           "max-age",
+		NonCompliantIf: `age > duration("1h")`,
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	d, err := e.Evaluate(Image{Age: time.Minute})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if !d.Compliant || d.Policy != "" {
+		t.Fatalf("Evaluate() = %+v, want a compliant decision naming no policy", d)
+	}
+}
+
+func TestNewEngineRejectsMalformedExpression(t *testing.T) {
+	_, err := NewEngine([]Policy{{
+		Name:           "broken",
+		NonCompliantIf: `age >`,
+	}})
+	if err == nil {
+		t.Fatal("NewEngine did not reject a malformed CEL expression")
+	}
+}
+
+func TestHashIsStableAndChangesWithPolicies(t *testing.T) {
+	policies := []Policy{{Name: "max-age", NonCompliantIf: `age > duration("1h")`}}
+
+	h1, err := Hash(policies)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	h2, err := Hash(policies)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if h1 != h2 {
+		t.Fatalf("Hash not stable across calls: %s != %s", h1, h2)
+	}
+
+	h3, err := Hash([]Policy{{Name: "max-age", NonCompliantIf: `age > duration("2h")`}})
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if h1 == h3 {
+		t.Fatal("Hash did not change when the policy expression changed")
+	}
+}
@@ -0,0 +1,114 @@
+// Package scancache memoizes per-image scan decisions on disk, keyed by
+// manifest digest, so a scanner run against a largely-unchanged node doesn't
+// re-fetch and re-evaluate every blob it already scanned recently.
+package scancache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Decision mirrors the three-way outcome the scanner partitions images
+// into.
+type Decision string
+
+const (
+	DecisionCompliant    Decision = "compliant"
+	DecisionNonCompliant Decision = "nonCompliant"
+	DecisionFailed       Decision = "failed"
+)
+
+// Entry is a single cached scan result.
+type Entry struct {
+	Decision       Decision  `json:"decision"`
+	Created        time.Time `json:"created"`
+	Policy         string    `json:"policy,omitempty"`
+	PolicyHash     string    `json:"policyHash"`
+	ScannerVersion string    `json:"scannerVersion"`
+	CachedAt       time.Time `json:"cachedAt"`
+}
+
+// Cache is a JSON-file-backed map from manifest digest to Entry. It is safe
+// for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]Entry
+}
+
+// Load reads the cache file at path. A missing file is treated as an empty
+// cache, not an error, so the first run on a node starts cold.
+func Load(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]Entry)}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("scancache: reading %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("scancache: parsing %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Lookup returns the cached entry for digest, if one exists, matches the
+// given policy hash and scanner version, and was cached within ttl.
+func (c *Cache) Lookup(digest, policyHash, scannerVersion string, ttl time.Duration) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[digest]
+	if !ok {
+		return Entry{}, false
+	}
+	if e.PolicyHash != policyHash || e.ScannerVersion != scannerVersion {
+		return Entry{}, false
+	}
+	if time.Since(e.CachedAt) > ttl {
+		return Entry{}, false
+	}
+
+	return e, true
+}
+
+// Store records (or overwrites) the entry for digest.
+func (c *Cache) Store(digest string, e Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[digest] = e
+}
+
+// Save writes the cache to its backing file, creating the parent directory
+// if needed.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("scancache: marshaling cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("scancache: creating %s: %w", filepath.Dir(c.path), err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("scancache: writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("scancache: renaming %s to %s: %w", tmp, c.path, err)
+	}
+
+	return nil
+}
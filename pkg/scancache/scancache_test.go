@@ -0,0 +1,87 @@
+package scancache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFileIsEmptyCache(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := c.Lookup("digest", "hash", "v1", time.Hour); ok {
+		t.Fatal("Lookup on empty cache returned a hit")
+	}
+}
+
+func TestLookupExpiresByTTL(t *testing.T) {
+	c, _ := Load(filepath.Join(t.TempDir(), "cache.json"))
+	c.Store("d1", Entry{
+		Decision:       DecisionCompliant,
+		PolicyHash:     "hash",
+		ScannerVersion: "v1",
+		CachedAt:       time.Now().Add(-2 * time.Hour),
+	})
+
+	if _, ok := c.Lookup("d1", "hash", "v1", time.Hour); ok {
+		t.Fatal("Lookup returned a hit past its TTL")
+	}
+	if _, ok := c.Lookup("d1", "hash", "v1", 3*time.Hour); !ok {
+		t.Fatal("Lookup missed an entry still within its TTL")
+	}
+}
+
+func TestLookupInvalidatesOnPolicyHashOrVersionChange(t *testing.T) {
+	c, _ := Load(filepath.Join(t.TempDir(), "cache.json"))
+	c.Store("d1", Entry{
+		Decision:       DecisionNonCompliant,
+		PolicyHash:     "hash-a",
+		ScannerVersion: "v1",
+		CachedAt:       time.Now(),
+	})
+
+	if _, ok := c.Lookup("d1", "hash-b", "v1", time.Hour); ok {
+		t.Fatal("Lookup returned a hit after the policy hash changed")
+	}
+	if _, ok := c.Lookup("d1", "hash-a", "v2", time.Hour); ok {
+		t.Fatal("Lookup returned a hit after the scanner version changed")
+	}
+	if _, ok := c.Lookup("d1", "hash-a", "v1", time.Hour); !ok {
+		t.Fatal("Lookup missed an entry whose hash and version still match")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := Entry{
+		Decision:       DecisionFailed,
+		Created:        time.Now().Add(-24 * time.Hour).Truncate(time.Second).UTC(),
+		PolicyHash:     "hash",
+		ScannerVersion: "v1",
+		CachedAt:       time.Now().Truncate(time.Second).UTC(),
+	}
+	c.Store("d1", want)
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load after Save: %v", err)
+	}
+	got, ok := reloaded.Lookup("d1", "hash", "v1", time.Hour)
+	if !ok {
+		t.Fatal("Lookup after reload found no entry")
+	}
+	if !got.Created.Equal(want.Created) || !got.CachedAt.Equal(want.CachedAt) || got.Decision != want.Decision {
+		t.Fatalf("reloaded entry = %+v, want %+v", got, want)
+	}
+}